@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/cgi"
 	"os"
 	"os/exec"
 	"runtime"
@@ -18,6 +19,16 @@ import (
 
 type vcsNewer func(h *HttpHandler) VcsHandle
 
+// vcsSuffix is appended to the vcs address advertised in the go-import meta
+// tag, since not every VCS keeps its working copy in a ".git"-like subdir.
+var vcsSuffix = map[string]string{
+	"git":    "/.git",
+	"hg":     "",
+	"bzr":    "",
+	"svn":    "",
+	"fossil": "",
+}
+
 type HttpHandler struct {
 	runner
 	httpAddr string
@@ -29,6 +40,12 @@ type HttpHandler struct {
 	newer    vcsNewer
 
 	handles map[string]VcsHandle
+
+	cgi    http.Handler
+	auths  map[string]authInfo
+	caCert []byte
+
+	revisions map[string]ShaInfo
 }
 
 func (h *HttpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -49,7 +66,21 @@ func (h *HttpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Fprintf(out, templ, h.httpAddr+"/"+handle.pkg(), h.vcsName, h.vcsAddr+handle.pkg()+"/.git")
+	if auth, ok := h.auths[handle.pkg()]; ok {
+		user, pass, got := r.BasicAuth()
+		if !got || user != auth.user || pass != auth.pass {
+			w.Header().Set("WWW-Authenticate", `Basic realm="govendor test"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if h.cgi != nil && strings.HasPrefix(strings.TrimPrefix(p, handle.pkg()), "/.git/") {
+		h.cgi.ServeHTTP(w, r)
+		return
+	}
+
+	fmt.Fprintf(out, templ, h.httpAddr+"/"+handle.pkg(), h.vcsName, h.vcsAddr+handle.pkg()+vcsSuffix[h.vcsName])
 }
 
 func (h *HttpHandler) Close() error {
@@ -70,6 +101,10 @@ func (h *HttpHandler) Setup() VcsHandle {
 }
 
 func NewHttpHandler(g *GopathTest, vcsName string) *HttpHandler {
+	return newHttpHandler(g, vcsName, false)
+}
+
+func newHttpHandler(g *GopathTest, vcsName string, useTLS bool) *HttpHandler {
 	// Test if git is installed. If it is, enable the git test.
 	// If enabled, start the http server and accept git server registrations.
 	l, err := net.Listen("tcp", "localhost:0")
@@ -89,6 +124,12 @@ func NewHttpHandler(g *GopathTest, vcsName string) *HttpHandler {
 		g:        g,
 
 		handles: make(map[string]VcsHandle, 6),
+
+		revisions: make(map[string]ShaInfo),
+	}
+	if useTLS {
+		l = h.wrapTLS(l)
+		h.l = l
 	}
 	go func() {
 		err = http.Serve(l, h)
@@ -107,29 +148,15 @@ func NewHttpHandler(g *GopathTest, vcsName string) *HttpHandler {
 	default:
 		panic("unknown vcs type")
 	case "git":
-		port := h.freePort()
-		h.vcsAddr = fmt.Sprintf("git://localhost:%d/", port)
-
-		h.runAsync(" Ready ", "daemon",
-			"--listen=localhost", fmt.Sprintf("--port=%d", port),
-			"--export-all", "--verbose", "--informative-errors",
-			"--base-path="+g.Path(""), h.cwd,
-		)
-		fmt.Printf("base-path %q, serve %q\n", g.Path(""), h.cwd)
-
-		h.newer = func(h *HttpHandler) VcsHandle {
-			return &gitVcsHandle{
-				vcsCommon: vcsCommon{
-					runner: runner{
-						execPath: execPath,
-						cwd:      h.g.Current(),
-						t:        h.g,
-					},
-					h:          h,
-					importPath: h.g.pkg,
-				},
-			}
-		}
+		h.setupGit(g, execPath)
+	case "hg":
+		h.setupHg(g, execPath)
+	case "bzr":
+		h.setupBzr(g, execPath)
+	case "svn":
+		h.setupSvn(g, execPath)
+	case "fossil":
+		h.setupFossil(g, execPath)
 	}
 	return h
 }
@@ -145,11 +172,74 @@ func (vcs *vcsCommon) pkg() string {
 	return vcs.importPath
 }
 
+// newVcsCommon builds the vcsCommon embedded by every VcsHandle
+// implementation, wired up to run execPath against h's working directory.
+func newVcsCommon(h *HttpHandler, execPath string) vcsCommon {
+	return vcsCommon{
+		runner: runner{
+			execPath: execPath,
+			cwd:      h.g.Current(),
+			t:        h.g,
+		},
+		h:          h,
+		importPath: h.g.pkg,
+	}
+}
+
 type VcsHandle interface {
 	remove()
 	pkg() string
 	create()
 	Commit() (rev string, commitTime string)
+
+	// CommitOn commits on branch, creating it off the current HEAD if it
+	// doesn't exist yet, so tests can build multi-branch histories and
+	// assert govendor picks up the correct tip per branch. Each
+	// implementation's ensureBranch helper is what makes this idempotent:
+	// it switches to branch, creating it only the first time, so CommitOn
+	// can be called repeatedly against the same branch.
+	CommitOn(branch string) (rev string, commitTime string)
+
+	// Tag tags the current revision.
+	Tag(name string)
+}
+
+// setupGit serves git over smart HTTP (GET .../.git/info/refs, POST
+// .../.git/git-upload-pack) through git-http-backend via CGI, rather than
+// spawning a separate "git daemon" on its own port. That keeps vcsAddr
+// on the same host and port as httpAddr and exercises the transport real govendor users
+// actually hit, instead of the anonymous git:// protocol.
+func (h *HttpHandler) setupGit(g *GopathTest, execPath string) {
+	h.enableGitHttpBackend()
+
+	h.newer = func(h *HttpHandler) VcsHandle {
+		return &gitVcsHandle{vcsCommon: newVcsCommon(h, execPath)}
+	}
+}
+
+// enableGitHttpBackend points h at "git-http-backend" over CGI, with
+// GIT_PROJECT_ROOT set to the gopath test root so relative repo paths like
+// pkg/.git resolve the same way they do for the working copy itself, and
+// repoints vcsAddr at the same HTTP server the meta tags are served from.
+func (h *HttpHandler) enableGitHttpBackend() {
+	backend, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		h.g.Fatalf("gt: failed to locate git-http-backend: %v", err)
+	}
+
+	h.cgi = &cgi.Handler{
+		Path: strings.TrimSpace(string(backend)) + "/git-http-backend",
+		Dir:  h.cwd,
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + h.g.Path(""),
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	scheme := "http"
+	if h.caCert != nil {
+		scheme = "https"
+	}
+	h.vcsAddr = scheme + "://" + h.httpAddr + "/"
 }
 
 type gitVcsHandle struct {
@@ -166,8 +256,32 @@ func (vcs *gitVcsHandle) create() {
 }
 
 func (vcs *gitVcsHandle) Commit() (rev string, commitTime string) {
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), "", rev, commitTime)
+	return rev, commitTime
+}
+
+func (vcs *gitVcsHandle) CommitOn(branch string) (rev string, commitTime string) {
+	vcs.ensureBranch(branch)
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), branch, rev, commitTime)
+	return rev, commitTime
+}
+
+func (vcs *gitVcsHandle) commitMsg(msg string) (rev string, commitTime string) {
 	vcs.run("add", "-A")
-	vcs.run("commit", "-a", "-m", "msg")
+	vcs.run("commit", "-a", "-m", msg)
+	return vcs.headRevInfo()
+}
+
+// merge merges branch into the current branch with a real merge commit
+// (--no-ff), so scripted histories can exercise merge-commit handling.
+func (vcs *gitVcsHandle) merge(branch string) (rev string, commitTime string) {
+	vcs.run("merge", "--no-ff", "--no-edit", branch)
+	return vcs.headRevInfo()
+}
+
+func (vcs *gitVcsHandle) headRevInfo() (rev string, commitTime string) {
 	out := vcs.run("show", "--pretty=format:%H@%ai", "-s")
 
 	line := strings.TrimSpace(string(out))
@@ -181,12 +295,42 @@ func (vcs *gitVcsHandle) Commit() (rev string, commitTime string) {
 	return rev, tm.UTC().Format(time.RFC3339)
 }
 
+func (vcs *gitVcsHandle) Tag(name string) {
+	vcs.run("tag", name)
+}
+
+// ensureBranch backs CommitOn; see the VcsHandle.CommitOn doc.
+func (vcs *gitVcsHandle) ensureBranch(branch string) {
+	if branch == "" {
+		return
+	}
+	if _, err := vcs.tryRun("checkout", branch); err != nil {
+		vcs.run("checkout", "-b", branch)
+	}
+}
+
+func (vcs *gitVcsHandle) branch(name string) {
+	vcs.run("checkout", "-b", name)
+}
+
+func (vcs *gitVcsHandle) checkout(name string) {
+	vcs.run("checkout", name)
+}
+
 type runner struct {
 	execPath string
 	cwd      string
 	t        *GopathTest
 }
 
+// tryRun is like run, but reports failure instead of calling t.Fatalf, for
+// callers that want to fall back to a different command on error.
+func (r *runner) tryRun(args ...string) ([]byte, error) {
+	cmd := exec.Command(r.execPath, args...)
+	cmd.Dir = r.cwd
+	return cmd.CombinedOutput()
+}
+
 func (r *runner) run(args ...string) []byte {
 	cmd := exec.Command(r.execPath, args...)
 	cmd.Dir = r.cwd
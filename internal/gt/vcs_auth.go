@@ -0,0 +1,27 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gt
+
+// authInfo is the basic-auth credential a single registered package must be
+// presented with before ServeHTTP will hand out its go-import meta tag or,
+// for git, let the smart-HTTP backend serve its repository. The daemons of
+// the other VCS backends serve their repositories without this check.
+type authInfo struct {
+	user string
+	pass string
+}
+
+// RequireAuth marks pkg as requiring HTTP basic auth with the given
+// credentials. Requests for pkg without a matching Authorization header get
+// a 401 instead of the go-import meta tag. For git, requests against the
+// smart-HTTP CGI backend (see setupGit) are covered by the same check, so a
+// fetch using credentials (via .netrc or the environment) can actually
+// complete.
+func (h *HttpHandler) RequireAuth(pkg, user, pass string) {
+	if h.auths == nil {
+		h.auths = make(map[string]authInfo)
+	}
+	h.auths[pkg] = authInfo{user: user, pass: pass}
+}
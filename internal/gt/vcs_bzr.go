@@ -0,0 +1,107 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (h *HttpHandler) setupBzr(g *GopathTest, execPath string) {
+	port := h.freePort()
+	h.vcsAddr = fmt.Sprintf("bzr://localhost:%d/", port)
+
+	h.runAsync("listening on port", "serve",
+		"--port", fmt.Sprintf("localhost:%d", port),
+		"--directory", g.Path(""),
+		"--allow-writes",
+	)
+
+	h.newer = func(h *HttpHandler) VcsHandle {
+		return &bzrVcsHandle{vcsCommon: newVcsCommon(h, execPath)}
+	}
+}
+
+type bzrVcsHandle struct {
+	vcsCommon
+}
+
+func (vcs *bzrVcsHandle) remove() {
+	delete(vcs.h.handles, vcs.pkg())
+}
+
+func (vcs *bzrVcsHandle) create() {
+	vcs.run("init", "--quiet")
+	vcs.run("whoami", "--branch", "tests <tests@govendor.io>")
+}
+
+func (vcs *bzrVcsHandle) Commit() (rev string, commitTime string) {
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), "", rev, commitTime)
+	return rev, commitTime
+}
+
+func (vcs *bzrVcsHandle) CommitOn(branch string) (rev string, commitTime string) {
+	vcs.ensureBranch(branch)
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), branch, rev, commitTime)
+	return rev, commitTime
+}
+
+// merge merges the colocated branch into the current one and commits the
+// result, so scripted histories can exercise merge-commit handling.
+func (vcs *bzrVcsHandle) merge(branch string) (rev string, commitTime string) {
+	vcs.run("merge", "-b", branch)
+	return vcs.commitMsg("merge " + branch)
+}
+
+// ensureBranch backs CommitOn; see the VcsHandle.CommitOn doc.
+func (vcs *bzrVcsHandle) ensureBranch(branch string) {
+	if branch == "" {
+		return
+	}
+	if _, err := vcs.tryRun("switch", branch); err != nil {
+		vcs.run("switch", "--create-branch", branch)
+	}
+}
+
+func (vcs *bzrVcsHandle) Tag(name string) {
+	vcs.run("tag", name)
+}
+
+func (vcs *bzrVcsHandle) branch(name string) {
+	vcs.run("switch", "--create-branch", name)
+}
+
+func (vcs *bzrVcsHandle) checkout(name string) {
+	vcs.run("switch", name)
+}
+
+func (vcs *bzrVcsHandle) commitMsg(msg string) (rev string, commitTime string) {
+	vcs.run("add", "--quiet")
+	vcs.run("commit", "--unchanged", "-m", msg)
+	out := vcs.run("log", "-l1", "--line")
+
+	line := strings.TrimSpace(string(out))
+	fields := strings.SplitN(line, ":", 2)
+	rev = strings.TrimSpace(fields[0])
+
+	tsOut := vcs.run("log", "-l1", "--show-ids")
+	for _, l := range strings.Split(string(tsOut), "\n") {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "timestamp:") {
+			raw := strings.TrimSpace(strings.TrimPrefix(l, "timestamp:"))
+			tm, err := time.Parse("Mon 2006-01-02 15:04:05 -0700", raw)
+			if err != nil {
+				panic("Failed to parse time: " + raw + " : " + err.Error())
+			}
+			commitTime = tm.UTC().Format(time.RFC3339)
+			break
+		}
+	}
+
+	return rev, commitTime
+}
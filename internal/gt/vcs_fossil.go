@@ -0,0 +1,122 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (h *HttpHandler) setupFossil(g *GopathTest, execPath string) {
+	port := h.freePort()
+	h.vcsAddr = fmt.Sprintf("http://localhost:%d/", port)
+
+	h.newer = func(h *HttpHandler) VcsHandle {
+		vcs := &fossilVcsHandle{vcsCommon: newVcsCommon(h, execPath)}
+		vcs.repoPath = h.g.Path(vcs.pkg() + ".fossil")
+		return vcs
+	}
+
+	// --repolist tells fossil to scan g.Path("") for *.fossil repos and
+	// serve each at a URL derived from its filename, rather than treating
+	// the directory argument as a single repository file.
+	h.runAsync("Listening for HTTP", "server",
+		"--port", fmt.Sprintf("%d", port),
+		"--localhost",
+		"--repolist",
+		g.Path(""),
+	)
+}
+
+type fossilVcsHandle struct {
+	vcsCommon
+
+	repoPath string
+}
+
+func (vcs *fossilVcsHandle) remove() {
+	delete(vcs.h.handles, vcs.pkg())
+}
+
+func (vcs *fossilVcsHandle) create() {
+	vcs.run("init", vcs.repoPath)
+	vcs.run("open", vcs.repoPath)
+	vcs.run("user", "default", "tests")
+}
+
+func (vcs *fossilVcsHandle) Commit() (rev string, commitTime string) {
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), "", rev, commitTime)
+	return rev, commitTime
+}
+
+func (vcs *fossilVcsHandle) CommitOn(branch string) (rev string, commitTime string) {
+	vcs.ensureBranch(branch)
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), branch, rev, commitTime)
+	return rev, commitTime
+}
+
+// merge merges branch into the current checkout and commits the result,
+// so scripted histories can exercise merge-commit handling.
+func (vcs *fossilVcsHandle) merge(branch string) (rev string, commitTime string) {
+	vcs.run("merge", branch)
+	return vcs.commitMsg("merge " + branch)
+}
+
+// ensureBranch backs CommitOn; see the VcsHandle.CommitOn doc.
+func (vcs *fossilVcsHandle) ensureBranch(branch string) {
+	if branch == "" {
+		return
+	}
+	if _, err := vcs.tryRun("checkout", branch); err != nil {
+		vcs.branch(branch)
+	}
+}
+
+func (vcs *fossilVcsHandle) Tag(name string) {
+	vcs.run("tag", "add", name, "current")
+}
+
+func (vcs *fossilVcsHandle) branch(name string) {
+	vcs.run("branch", "new", name, "current")
+	vcs.checkout(name)
+}
+
+func (vcs *fossilVcsHandle) checkout(name string) {
+	vcs.run("checkout", name)
+}
+
+func (vcs *fossilVcsHandle) commitMsg(msg string) (rev string, commitTime string) {
+	vcs.run("add", ".")
+	out := vcs.run("commit", "-m", msg, "--user-override", "tests")
+
+	for _, l := range strings.Split(string(out), "\n") {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "New_Version:") {
+			rev = strings.TrimSpace(strings.TrimPrefix(l, "New_Version:"))
+			break
+		}
+	}
+
+	infoOut := vcs.run("info", rev)
+	for _, l := range strings.Split(string(infoOut), "\n") {
+		if strings.HasPrefix(l, "uuid:") {
+			fields := strings.Fields(l)
+			if len(fields) >= 4 {
+				raw := fields[2] + " " + fields[3]
+				tm, err := time.Parse("2006-01-02 15:04:05", raw)
+				if err != nil {
+					panic("Failed to parse time: " + raw + " : " + err.Error())
+				}
+				commitTime = tm.UTC().Format(time.RFC3339)
+			}
+			break
+		}
+	}
+
+	return rev, commitTime
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// setupHg serves every hg package under g.Path("") through a single
+// hgweb, the same multi-repo, root-based model the other four VCS
+// backends use (git's GIT_PROJECT_ROOT, bzr's --directory, svn's --root,
+// fossil's repository directory). A
+// plain "hg serve -R <dir>" instead binds to one fixed, already-existing
+// repository, which would both limit a handler to a single hg package and
+// abort immediately since no repo exists yet at NewHttpHandler time.
+func (h *HttpHandler) setupHg(g *GopathTest, execPath string) {
+	port := h.freePort()
+	h.vcsAddr = fmt.Sprintf("http://localhost:%d/", port)
+
+	confPath := filepath.Join(g.Path(""), ".hgweb.conf")
+	conf := fmt.Sprintf("[paths]\n/ = %s/*\n", g.Path(""))
+	if err := os.WriteFile(confPath, []byte(conf), 0644); err != nil {
+		g.Fatalf("gt: failed to write hgweb config %q: %v", confPath, err)
+	}
+
+	h.runAsync("listening at", "serve",
+		"--port", fmt.Sprintf("%d", port),
+		"--address", "localhost",
+		"--webdir-conf", confPath,
+	)
+
+	h.newer = func(h *HttpHandler) VcsHandle {
+		return &hgVcsHandle{vcsCommon: newVcsCommon(h, execPath)}
+	}
+}
+
+type hgVcsHandle struct {
+	vcsCommon
+}
+
+func (vcs *hgVcsHandle) remove() {
+	delete(vcs.h.handles, vcs.pkg())
+}
+
+func (vcs *hgVcsHandle) create() {
+	vcs.run("init")
+	vcs.run("--config", "ui.username=tests <tests@govendor.io>", "id")
+}
+
+func (vcs *hgVcsHandle) Commit() (rev string, commitTime string) {
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), "", rev, commitTime)
+	return rev, commitTime
+}
+
+func (vcs *hgVcsHandle) CommitOn(branch string) (rev string, commitTime string) {
+	vcs.ensureBranch(branch)
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), branch, rev, commitTime)
+	return rev, commitTime
+}
+
+// merge merges branch into the current branch and commits the result, so
+// scripted histories can exercise merge-commit handling.
+func (vcs *hgVcsHandle) merge(branch string) (rev string, commitTime string) {
+	vcs.run("merge", branch)
+	return vcs.commitMsg("merge " + branch)
+}
+
+// ensureBranch backs CommitOn; see the VcsHandle.CommitOn doc.
+func (vcs *hgVcsHandle) ensureBranch(branch string) {
+	if branch == "" {
+		return
+	}
+	if _, err := vcs.tryRun("update", branch); err != nil {
+		vcs.run("branch", branch)
+	}
+}
+
+func (vcs *hgVcsHandle) commitMsg(msg string) (rev string, commitTime string) {
+	vcs.run("addremove")
+	vcs.run("--config", "ui.username=tests <tests@govendor.io>", "commit", "-m", msg)
+	out := vcs.run("log", "-r", ".", "--template", "{node}@{date|rfc3339date}")
+
+	line := strings.TrimSpace(string(out))
+	ss := strings.SplitN(line, "@", 2)
+	rev = ss[0]
+	tm, err := time.Parse(time.RFC3339, ss[1])
+	if err != nil {
+		panic("Failed to parse time: " + ss[1] + " : " + err.Error())
+	}
+
+	return rev, tm.UTC().Format(time.RFC3339)
+}
+
+func (vcs *hgVcsHandle) Tag(name string) {
+	vcs.run("--config", "ui.username=tests <tests@govendor.io>", "tag", name)
+}
+
+func (vcs *hgVcsHandle) branch(name string) {
+	vcs.run("branch", name)
+}
+
+func (vcs *hgVcsHandle) checkout(name string) {
+	vcs.run("update", name)
+}
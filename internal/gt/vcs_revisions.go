@@ -0,0 +1,40 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gt
+
+// ShaInfo is a snapshot of a single VcsHandle revision, recorded every time
+// Commit or CommitOn runs. It mirrors the {protocol, branch, sha} tuples VCS
+// helpers record per source to detect upstream changes on devel packages.
+type ShaInfo struct {
+	Pkg        string
+	Branch     string
+	Rev        string
+	CommitTime string
+}
+
+// Revisions returns the latest revision recorded for each key across all of
+// h's handles, so tests can assert govendor picked up the right tip per
+// branch. CommitOn, and a script's commit and merge lines after a branch or
+// checkout line, record under "pkg@branch"; Commit records under "pkg"
+// whichever branch is checked out. A later record replaces an earlier one
+// with the same key.
+func (h *HttpHandler) Revisions() map[string]ShaInfo {
+	return h.revisions
+}
+
+// recordRevision saves the result of a Commit/CommitOn call. branch is
+// empty for the handle's default branch.
+func (h *HttpHandler) recordRevision(pkg, branch, rev, commitTime string) {
+	key := pkg
+	if branch != "" {
+		key = pkg + "@" + branch
+	}
+	h.revisions[key] = ShaInfo{
+		Pkg:        pkg,
+		Branch:     branch,
+		Rev:        rev,
+		CommitTime: commitTime,
+	}
+}
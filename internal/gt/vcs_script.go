@@ -0,0 +1,197 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// scriptOps is the subset of VcsHandle that SetupScript needs beyond the
+// public interface, implemented by every concrete VcsHandle type.
+type scriptOps interface {
+	VcsHandle
+	commitMsg(msg string) (rev string, commitTime string)
+	merge(branch string) (rev string, commitTime string)
+	branch(name string)
+	checkout(name string)
+}
+
+// SetupScript builds a VcsHandle from a small declarative script instead of
+// a sequence of imperative Go calls, so tests can describe non-trivial
+// histories (several commits, tags, branches) without writing Go glue for
+// each one. Each non-empty, non-comment line is one instruction:
+//
+//	handle git example.com/foo   // declare the vcs and package under test
+//	cp a.go pkg/a.go              // copy a file (relative to the script) into the repo
+//	commit msg="v1"               // commit with the given message
+//	tag v1.0.0                    // tag the current revision
+//	branch feature                // create and switch to a new branch
+//	checkout main                 // switch to an existing branch
+//	merge feature                 // merge a branch into the current one, with a commit
+//
+// Lines starting with '#' are comments. The returned VcsHandle is the one
+// declared by the "handle" line, left checked out at wherever the last
+// "checkout" or "branch" line left it.
+func (h *HttpHandler) SetupScript(path string) VcsHandle {
+	f, err := os.Open(path)
+	if err != nil {
+		h.g.Fatalf("gt: failed to open script %q: %v", path, err)
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(path)
+	var handle VcsHandle
+	var ops scriptOps
+	var branch string // current branch, "" meaning the default
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields, err := splitScriptLine(line)
+		if err != nil {
+			h.g.Fatalf("gt: %s:%d: %v", path, lineNo, err)
+		}
+		cmd, args := fields[0], fields[1:]
+
+		switch cmd {
+		case "handle":
+			if handle != nil {
+				h.g.Fatalf("gt: %s:%d: only one handle per script is supported", path, lineNo)
+			}
+			if len(args) != 2 {
+				h.g.Fatalf("gt: %s:%d: usage: handle <vcs> <pkg>", path, lineNo)
+			}
+			if args[0] != h.vcsName {
+				h.g.Fatalf("gt: %s:%d: script declares vcs %q, handler is serving %q", path, lineNo, args[0], h.vcsName)
+			}
+			if args[1] != h.g.pkg {
+				h.g.Fatalf("gt: %s:%d: script declares package %q, test is in %q", path, lineNo, args[1], h.g.pkg)
+			}
+			handle = h.Setup()
+			var isScriptable bool
+			ops, isScriptable = handle.(scriptOps)
+			if !isScriptable {
+				h.g.Fatalf("gt: %s:%d: vcs %q does not support scripted repositories", path, lineNo, args[0])
+			}
+		case "cp":
+			if ops == nil {
+				h.g.Fatalf("gt: %s:%d: cp before handle", path, lineNo)
+			}
+			if len(args) != 2 {
+				h.g.Fatalf("gt: %s:%d: usage: cp <src> <dst>", path, lineNo)
+			}
+			copyScriptFile(h.g, filepath.Join(dir, args[0]), filepath.Join(h.cwd, args[1]))
+		case "commit":
+			if ops == nil {
+				h.g.Fatalf("gt: %s:%d: commit before handle", path, lineNo)
+			}
+			msg := "msg"
+			for _, arg := range args {
+				if rest, ok := strings.CutPrefix(arg, "msg="); ok {
+					m, err := strconv.Unquote(rest)
+					if err != nil {
+						h.g.Fatalf("gt: %s:%d: invalid msg: %v", path, lineNo, err)
+					}
+					msg = m
+				}
+			}
+			rev, commitTime := ops.commitMsg(msg)
+			h.recordRevision(handle.pkg(), branch, rev, commitTime)
+		case "tag":
+			if handle == nil || len(args) != 1 {
+				h.g.Fatalf("gt: %s:%d: usage: tag <name>", path, lineNo)
+			}
+			handle.Tag(args[0])
+		case "branch":
+			if ops == nil || len(args) != 1 {
+				h.g.Fatalf("gt: %s:%d: usage: branch <name>", path, lineNo)
+			}
+			ops.branch(args[0])
+			branch = args[0]
+		case "checkout":
+			if ops == nil || len(args) != 1 {
+				h.g.Fatalf("gt: %s:%d: usage: checkout <name>", path, lineNo)
+			}
+			ops.checkout(args[0])
+			branch = args[0]
+		case "merge":
+			if ops == nil || len(args) != 1 {
+				h.g.Fatalf("gt: %s:%d: usage: merge <branch>", path, lineNo)
+			}
+			rev, commitTime := ops.merge(args[0])
+			h.recordRevision(handle.pkg(), branch, rev, commitTime)
+		default:
+			h.g.Fatalf("gt: %s:%d: unknown script command %q", path, lineNo, cmd)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		h.g.Fatalf("gt: failed to read script %q: %v", path, err)
+	}
+	if handle == nil {
+		h.g.Fatalf("gt: script %q declared no handle", path)
+	}
+	return handle
+}
+
+// splitScriptLine tokenizes a script line on whitespace, keeping quoted
+// key="value" pairs (used by "commit msg=\"...\"") intact.
+func splitScriptLine(line string) ([]string, error) {
+	var fields []string
+	var cur strings.Builder
+	inQuotes := false
+	flush := func() {
+		if cur.Len() > 0 {
+			fields = append(fields, cur.String())
+			cur.Reset()
+		}
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			cur.WriteByte(c)
+		case (c == ' ' || c == '\t') && !inQuotes:
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	flush()
+	return fields, nil
+}
+
+func copyScriptFile(g *GopathTest, src, dst string) {
+	in, err := os.Open(src)
+	if err != nil {
+		g.Fatalf("gt: failed to open %q: %v", src, err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		g.Fatalf("gt: failed to create %q: %v", filepath.Dir(dst), err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		g.Fatalf("gt: failed to create %q: %v", dst, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		g.Fatalf("gt: failed to copy %q to %q: %v", src, dst, err)
+	}
+}
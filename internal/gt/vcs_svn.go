@@ -0,0 +1,127 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gt
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+func (h *HttpHandler) setupSvn(g *GopathTest, execPath string) {
+	adminPath, _ := exec.LookPath("svnadmin")
+	if len(adminPath) == 0 {
+		g.Skip("svnadmin not found")
+	}
+
+	port := h.freePort()
+	h.vcsAddr = fmt.Sprintf("svn://localhost:%d/", port)
+
+	h.runAsync("", "svnserve",
+		"-d", "--foreground",
+		"--listen-host=localhost", fmt.Sprintf("--listen-port=%d", port),
+		"--root="+g.Path(""),
+	)
+
+	h.newer = func(h *HttpHandler) VcsHandle {
+		vcs := &svnVcsHandle{vcsCommon: newVcsCommon(h, execPath)}
+		vcs.adminPath = adminPath
+		vcs.repoPath = h.g.Path(vcs.pkg() + ".repo")
+		return vcs
+	}
+}
+
+type svnVcsHandle struct {
+	vcsCommon
+
+	adminPath string
+	repoPath  string
+}
+
+func (vcs *svnVcsHandle) remove() {
+	delete(vcs.h.handles, vcs.pkg())
+}
+
+func (vcs *svnVcsHandle) create() {
+	cmd := exec.Command(vcs.adminPath, "create", vcs.repoPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		vcs.t.Fatalf("Failed to run %q create %q: %v\n%s", vcs.adminPath, vcs.repoPath, err, out)
+	}
+	vcs.run("checkout", "file://"+vcs.repoPath, vcs.cwd)
+}
+
+func (vcs *svnVcsHandle) Commit() (rev string, commitTime string) {
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), "", rev, commitTime)
+	return rev, commitTime
+}
+
+func (vcs *svnVcsHandle) CommitOn(branch string) (rev string, commitTime string) {
+	vcs.ensureBranch(branch)
+	rev, commitTime = vcs.commitMsg("msg")
+	vcs.h.recordRevision(vcs.pkg(), branch, rev, commitTime)
+	return rev, commitTime
+}
+
+// merge merges branches/name into the current working copy and commits
+// the result, so scripted histories can exercise merge-commit handling.
+func (vcs *svnVcsHandle) merge(branch string) (rev string, commitTime string) {
+	vcs.run("merge", "../branches/"+branch, ".")
+	return vcs.commitMsg("merge " + branch)
+}
+
+// ensureBranch backs CommitOn; see the VcsHandle.CommitOn doc. It switches
+// the working copy to branches/name, creating that branch from the current
+// working copy first if needed.
+func (vcs *svnVcsHandle) ensureBranch(branch string) {
+	if branch == "" {
+		return
+	}
+	if _, err := vcs.tryRun("switch", "../branches/"+branch); err != nil {
+		vcs.branch(branch)
+	}
+}
+
+// Tag copies the current working copy into tags/name, the conventional svn
+// equivalent of a git tag.
+func (vcs *svnVcsHandle) Tag(name string) {
+	vcs.run("copy", ".", "../tags/"+name)
+	vcs.run("commit", "-m", "tag "+name)
+}
+
+// branch copies the current working copy into branches/name and switches to
+// it.
+func (vcs *svnVcsHandle) branch(name string) {
+	vcs.run("copy", ".", "../branches/"+name)
+	vcs.run("commit", "-m", "branch "+name)
+	vcs.checkout(name)
+}
+
+func (vcs *svnVcsHandle) checkout(name string) {
+	vcs.run("switch", "../branches/"+name)
+}
+
+func (vcs *svnVcsHandle) commitMsg(msg string) (rev string, commitTime string) {
+	vcs.run("add", "--force", "--quiet", ".")
+	out := vcs.run("commit", "-m", msg)
+
+	for _, l := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(l, "Committed revision") {
+			l = strings.TrimSuffix(strings.TrimPrefix(l, "Committed revision "), ".")
+			rev = strings.TrimSpace(l)
+			break
+		}
+	}
+
+	infoOut := vcs.run("info", "--show-item=last-changed-date", "-r", rev)
+	raw := strings.TrimSpace(string(infoOut))
+	tm, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		panic("Failed to parse time: " + raw + " : " + err.Error())
+	}
+
+	return rev, tm.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+)
+
+// NewHttpsHandler is NewHttpHandler's HTTPS sibling: it serves the same
+// go-import meta tags, but over a TLS listener backed by an in-memory CA and
+// leaf certificate generated at startup. Use (*HttpHandler).CACert to fetch
+// the CA so it can be handed to a child go/git process via
+// GIT_SSL_CAINFO or SSL_CERT_FILE.
+func NewHttpsHandler(g *GopathTest, vcsName string) *HttpHandler {
+	return newHttpHandler(g, vcsName, true)
+}
+
+// CACert returns the PEM-encoded CA certificate that signed this handler's
+// leaf certificate, or nil if the handler isn't serving over TLS.
+func (h *HttpHandler) CACert() []byte {
+	return h.caCert
+}
+
+// wrapTLS generates a throwaway CA and a leaf certificate for localhost,
+// records the CA's PEM encoding on h, and wraps l in a tls.Listener
+// presenting that leaf certificate.
+func (h *HttpHandler) wrapTLS(l net.Listener) net.Listener {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		h.g.Fatalf("gt: failed to generate CA key: %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "govendor test CA"},
+		NotBefore:             time.Unix(0, 0),
+		NotAfter:              time.Unix(0, 0).Add(100 * 365 * 24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		h.g.Fatalf("gt: failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		h.g.Fatalf("gt: failed to parse CA certificate: %v", err)
+	}
+
+	leafKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		h.g.Fatalf("gt: failed to generate leaf key: %v", err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    caTmpl.NotBefore,
+		NotAfter:     caTmpl.NotAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, caCert, &leafKey.PublicKey, caKey)
+	if err != nil {
+		h.g.Fatalf("gt: failed to create leaf certificate: %v", err)
+	}
+
+	h.caCert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{leafDER, caDER},
+		PrivateKey:  leafKey,
+	}
+	return tls.NewListener(l, &tls.Config{Certificates: []tls.Certificate{cert}})
+}